@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	mrand "math/rand"
+	"sort"
+	"time"
+)
+
+// DPoSParams configures how many delegates are active at once and how many
+// consecutive blocks each delegate produces before the slate rotates to the
+// next one.
+type DPoSParams struct {
+	DelegateCount int
+	BlocksPerSlot int
+}
+
+// DPoSNetwork is a delegated proof-of-stake consensus engine: stake-holders
+// vote for candidates, the top DelegateCount candidates become the active
+// Delegates, and blocks must be produced by whichever delegate is on turn
+// for the current slot.
+type DPoSNetwork struct {
+	Blockchain     []*Block
+	BlockchainHead *Block
+	Candidates     map[string]int
+	Delegates      []string
+	Params         DPoSParams
+}
+
+// NewDPoSNetwork starts a DPoS chain from the given genesis block.
+func NewDPoSNetwork(genesis *Block, params DPoSParams) *DPoSNetwork {
+	return &DPoSNetwork{
+		Blockchain:     []*Block{genesis},
+		BlockchainHead: genesis,
+		Candidates:     make(map[string]int),
+		Params:         params,
+	}
+}
+
+// RegisterCandidate enters a new delegate candidate with zero votes.
+func (d *DPoSNetwork) RegisterCandidate(candidate string) {
+	if _, ok := d.Candidates[candidate]; !ok {
+		d.Candidates[candidate] = 0
+	}
+}
+
+// Vote adds weight to a candidate's vote total. The new tally only takes
+// effect for the active Delegates slate at the next epoch boundary (see
+// GenerateNewBlock), so an epoch's round-robin schedule stays stable for its
+// whole duration instead of reshuffling out from under it mid-epoch. The
+// very first vote is the exception: with no slate elected yet, there is
+// nothing in progress to preserve, so it elects one immediately to bootstrap
+// the chain.
+func (d *DPoSNetwork) Vote(candidate string, weight int) error {
+	if _, ok := d.Candidates[candidate]; !ok {
+		return fmt.Errorf("candidate %q is not registered", candidate)
+	}
+	d.Candidates[candidate] += weight
+	if len(d.Delegates) == 0 {
+		d.electDelegates()
+	}
+	return nil
+}
+
+// electDelegates sorts candidates by vote weight (ties broken by name, for
+// determinism) and keeps the top Params.DelegateCount as the active slate.
+func (d *DPoSNetwork) electDelegates() {
+	type entry struct {
+		name   string
+		weight int
+	}
+	entries := make([]entry, 0, len(d.Candidates))
+	for name, weight := range d.Candidates {
+		entries = append(entries, entry{name, weight})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].weight != entries[j].weight {
+			return entries[i].weight > entries[j].weight
+		}
+		return entries[i].name < entries[j].name
+	})
+
+	count := d.Params.DelegateCount
+	if count > len(entries) {
+		count = len(entries)
+	}
+	delegates := make([]string, count)
+	for i := 0; i < count; i++ {
+		delegates[i] = entries[i].name
+	}
+	d.Delegates = delegates
+}
+
+// CurrentDelegates returns a copy of the active, ordered delegate slate.
+func (d *DPoSNetwork) CurrentDelegates() []string {
+	out := make([]string, len(d.Delegates))
+	copy(out, d.Delegates)
+	return out
+}
+
+// NextProducer returns the delegate expected to produce the block at slot.
+func (d *DPoSNetwork) NextProducer(slot int) (string, error) {
+	if len(d.Delegates) == 0 {
+		return "", errors.New("no active delegates")
+	}
+	return d.Delegates[slot%len(d.Delegates)], nil
+}
+
+// epochAndSlot derives the epoch and in-epoch slot for the next block from
+// how many blocks have already been produced.
+func (d *DPoSNetwork) epochAndSlot() (epoch, slot int) {
+	epochLen := len(d.Delegates) * d.Params.BlocksPerSlot
+	if epochLen == 0 {
+		return 0, 0
+	}
+	blockIndex := len(d.Blockchain) - 1
+	return blockIndex / epochLen, (blockIndex % epochLen) / d.Params.BlocksPerSlot
+}
+
+// reshuffle deterministically reorders the delegate slate using a seed
+// derived from the last block of the previous epoch, so every honest node
+// computing the same chain arrives at the same new order.
+func (d *DPoSNetwork) reshuffle() {
+	r := mrand.New(mrand.NewSource(seedFromHash(d.BlockchainHead.Hash)))
+	r.Shuffle(len(d.Delegates), func(i, j int) {
+		d.Delegates[i], d.Delegates[j] = d.Delegates[j], d.Delegates[i]
+	})
+}
+
+func seedFromHash(hash string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(hash))
+	return int64(h.Sum64())
+}
+
+// GenerateNewBlock produces the next block if producer is the delegate on
+// turn for the current slot, reshuffling the slate first if a new epoch has
+// started.
+func (d *DPoSNetwork) GenerateNewBlock(producer *Node) (*Block, error) {
+	if len(d.Delegates) == 0 {
+		return nil, errors.New("no active delegate slate; call Vote to elect delegates")
+	}
+
+	epochLen := len(d.Delegates) * d.Params.BlocksPerSlot
+	blockIndex := len(d.Blockchain) - 1
+	if epochLen > 0 && blockIndex > 0 && blockIndex%epochLen == 0 {
+		// epoch boundary: promote whatever the vote tally looks like now,
+		// then reshuffle the newly-elected slate's order.
+		d.electDelegates()
+		d.reshuffle()
+	}
+
+	epoch, slot := d.epochAndSlot()
+	expected, err := d.NextProducer(slot)
+	if err != nil {
+		return nil, err
+	}
+	if producer.Address != expected {
+		return nil, fmt.Errorf("validator %s is not the expected producer for slot %d (expected %s)", producer.Address, slot, expected)
+	}
+
+	newBlock := &Block{
+		Timestamp:     time.Now().String(),
+		PrevHash:      d.BlockchainHead.Hash,
+		Hash:          NewBlockHash(d.BlockchainHead),
+		ValidatorAddr: producer.Address,
+		Slot:          slot,
+		Epoch:         epoch,
+	}
+
+	if err := d.ValidateBlockCandidate(newBlock); err != nil {
+		return nil, err
+	}
+	d.Blockchain = append(d.Blockchain, newBlock)
+	d.BlockchainHead = newBlock
+	return newBlock, nil
+}
+
+// ValidateBlockCandidate checks chain continuity and that newBlock was
+// produced by the delegate whose turn its slot actually is.
+func (d *DPoSNetwork) ValidateBlockCandidate(newBlock *Block) error {
+	if d.BlockchainHead.Hash != newBlock.PrevHash {
+		return errors.New("blockchain HEAD hash is not equal to new block previous hash")
+	}
+
+	if d.BlockchainHead.Timestamp >= newBlock.Timestamp {
+		return errors.New("blockchain HEAD timestamp is greater than or equal to new block timestamp")
+	}
+
+	if NewBlockHash(d.BlockchainHead) != newBlock.Hash {
+		return errors.New("new block hash of blockchain HEAD does not equal new block hash")
+	}
+
+	expected, err := d.NextProducer(newBlock.Slot)
+	if err != nil {
+		return err
+	}
+	if newBlock.ValidatorAddr != expected {
+		return fmt.Errorf("block produced out of turn: slot %d expects %s, got %s", newBlock.Slot, expected, newBlock.ValidatorAddr)
+	}
+	return nil
+}
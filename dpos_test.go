@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestVoteDefersSlateToEpochBoundary(t *testing.T) {
+	genesis := &Block{Hash: "genesis"}
+	d := NewDPoSNetwork(genesis, DPoSParams{DelegateCount: 1, BlocksPerSlot: 2})
+	d.RegisterCandidate("alice")
+	d.RegisterCandidate("bob")
+	d.RegisterCandidate("carol")
+
+	if err := d.Vote("alice", 10); err != nil {
+		t.Fatalf("Vote: %v", err)
+	}
+	if got := d.CurrentDelegates(); len(got) != 1 || got[0] != "alice" {
+		t.Fatalf("expected alice elected by the bootstrap vote, got %v", got)
+	}
+
+	// carol outvotes alice mid-epoch; the live slate must not move yet.
+	if err := d.Vote("carol", 100); err != nil {
+		t.Fatalf("Vote: %v", err)
+	}
+	if got := d.CurrentDelegates(); got[0] != "alice" {
+		t.Fatalf("slate changed before the epoch boundary: %v", got)
+	}
+
+	// blocks 1 and 2 are still alice's epoch (epochLen = 1*2 = 2).
+	if _, err := d.GenerateNewBlock(&Node{Address: "alice"}); err != nil {
+		t.Fatalf("block 1: %v", err)
+	}
+	if _, err := d.GenerateNewBlock(&Node{Address: "alice"}); err != nil {
+		t.Fatalf("block 2: %v", err)
+	}
+
+	// block 3 crosses the epoch boundary; carol's tally is now promoted.
+	if _, err := d.GenerateNewBlock(&Node{Address: "carol"}); err != nil {
+		t.Fatalf("block 3 (epoch boundary): %v", err)
+	}
+	if got := d.CurrentDelegates(); got[0] != "carol" {
+		t.Fatalf("expected carol promoted at the epoch boundary, got %v", got)
+	}
+}
+
+func TestOutOfTurnBlockRejected(t *testing.T) {
+	genesis := &Block{Hash: "genesis"}
+	d := NewDPoSNetwork(genesis, DPoSParams{DelegateCount: 2, BlocksPerSlot: 1})
+	d.RegisterCandidate("alice")
+	d.RegisterCandidate("bob")
+	if err := d.Vote("alice", 10); err != nil {
+		t.Fatalf("Vote: %v", err)
+	}
+	if err := d.Vote("bob", 5); err != nil {
+		t.Fatalf("Vote: %v", err)
+	}
+
+	// slate is [alice, bob]; slot 0 belongs to alice, not bob.
+	if _, err := d.GenerateNewBlock(&Node{Address: "bob"}); err == nil {
+		t.Fatal("expected an out-of-turn block to be rejected")
+	}
+}
+
+func TestReshuffleDeterministic(t *testing.T) {
+	build := func() *DPoSNetwork {
+		genesis := &Block{Hash: "genesis"}
+		d := NewDPoSNetwork(genesis, DPoSParams{DelegateCount: 3, BlocksPerSlot: 1})
+		for _, c := range []string{"alice", "bob", "carol"} {
+			d.RegisterCandidate(c)
+		}
+		_ = d.Vote("alice", 30)
+		_ = d.Vote("bob", 20)
+		_ = d.Vote("carol", 10)
+		return d
+	}
+
+	d1, d2 := build(), build()
+	d1.reshuffle()
+	d2.reshuffle()
+
+	if len(d1.Delegates) != len(d2.Delegates) {
+		t.Fatalf("slate length mismatch: %v vs %v", d1.Delegates, d2.Delegates)
+	}
+	for i := range d1.Delegates {
+		if d1.Delegates[i] != d2.Delegates[i] {
+			t.Fatalf("reshuffle is not deterministic: %v vs %v", d1.Delegates, d2.Delegates)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -15,6 +16,11 @@ type Block struct {
 	PrevHash      string
 	Hash          string
 	ValidatorAddr string
+	Slot          int
+	Epoch         int
+	Round         uint64
+	BeaconEntry   []byte
+	Transactions  []*Transaction
 }
 
 func (n PoSNetwork) PrintBlockchainInfo() {
@@ -29,45 +35,198 @@ func (b Block) PrintBlockInfo() {
 	fmt.Println("\tPrevious Hash:", b.PrevHash)
 	fmt.Println("\tHash:", b.Hash)
 	fmt.Println("\tValidator Address:", b.ValidatorAddr)
+	fmt.Println("\tSlot:", b.Slot)
+	fmt.Println("\tEpoch:", b.Epoch)
+	fmt.Println("\tTransactions:", len(b.Transactions))
 }
 
 type PoSNetwork struct {
 	Blockchain     []*Block
 	BlockchainHead *Block
 	Validators     []*Node
+	Params         PoSParams
+	Beacon         RandomnessBeacon
+	Mempool        *Mempool
+	UTXO           UTXOSet
+	Store          Store
+	Orphans        map[string][]*Block
+	SlashingPolicy SlashingPolicy
+	SignRecords    map[signKey]string
+}
+
+// PoSParams configures the coin-age weighted selection used by SelectWinner
+// and how a block producer is rewarded for including transactions. MinAge
+// and MaxAge are round counts, matching how CoinAge measures age.
+type PoSParams struct {
+	MinAge        int
+	MaxAge        int
+	HashTarget    func(*Block) string
+	MaxTxPerBlock int
+	BlockReward   int
+}
+
+// DefaultPoSParams returns the coin-age bounds used when a PoSNetwork is
+// constructed without explicit Params: a node must sit idle for 30 rounds
+// before it is eligible, and ages beyond 90 rounds grant no further weight.
+func DefaultPoSParams() PoSParams {
+	return PoSParams{
+		MinAge:     30,
+		MaxAge:     90,
+		HashTarget: NewBlockHash,
+	}
+}
+
+func (n PoSNetwork) hashTarget() func(*Block) string {
+	if n.Params.HashTarget != nil {
+		return n.Params.HashTarget
+	}
+	return NewBlockHash
 }
 
 type Node struct {
-	Stake   int
-	Address string
+	Stake             int
+	Address           string
+	LastStakeUseRound uint64
+	JailedUntil       uint64
+}
+
+// CoinAge reports how many rounds of stake-age Node has accrued since it
+// last produced a block (or since it was minted, if it never has). Age is
+// measured in rounds rather than wall-clock time so that it is a pure
+// function of chain state: every node computing eligibility for the same
+// round reaches the same answer regardless of clock skew or how long it
+// took to get there, which beacon-driven selection depends on (see
+// SelectWinner).
+func (n Node) CoinAge(round uint64) int {
+	if round <= n.LastStakeUseRound {
+		return 0
+	}
+	return int(round - n.LastStakeUseRound)
 }
 
 func (n PoSNetwork) GenerateNewBlock(Validator *Node) ([]*Block, *Block, error) {
+	round := uint64(len(n.Blockchain))
+
 	if err := n.ValidateBlockchain(); err != nil {
-		Validator.Stake -= 10
+		n.slash(Validator, FaultUnavailable, round)
 		return n.Blockchain, n.BlockchainHead, err
 	}
 
 	currentTime := time.Now().String()
 
+	txs, reward := n.drainMempool()
+	if reward > 0 {
+		// credit the producer's reward through the UTXO set too, the same
+		// as any other chain, so there is ever actually something for a
+		// spending transaction to reference.
+		txs = append([]*Transaction{NewCoinbaseTx(Validator.Address, reward)}, txs...)
+	}
+
 	newBlock := &Block{
 		Timestamp:     currentTime,
 		PrevHash:      n.BlockchainHead.Hash,
-		Hash:          NewBlockHash(n.BlockchainHead),
+		Hash:          n.hashTarget()(n.BlockchainHead),
 		ValidatorAddr: Validator.Address,
+		Round:         round,
+		Transactions:  txs,
+	}
+
+	if n.Beacon != nil {
+		entry, err := n.Beacon.EntryAt(round)
+		if err != nil {
+			n.slash(Validator, FaultUnavailable, round)
+			return n.Blockchain, n.BlockchainHead, err
+		}
+		newBlock.BeaconEntry = entry
 	}
 
 	if err := n.ValidateBlockCandidate(newBlock); err != nil {
-		Validator.Stake -= 10
+		n.slash(Validator, classifyFault(err), round)
 		return n.Blockchain, n.BlockchainHead, err
 	} else {
 		n.Blockchain = append(n.Blockchain, newBlock)
+		// consume the validator's coin-age now that it has signed a block
+		Validator.LastStakeUseRound = round
+		if n.UTXO != nil {
+			for _, tx := range txs {
+				n.UTXO.Apply(tx)
+			}
+		}
+		if n.Mempool != nil {
+			n.Mempool.Remove(txs)
+		}
+		Validator.Stake += reward
+		if n.Store != nil {
+			if err := n.Store.CommitBlock(newBlock); err != nil {
+				return n.Blockchain, n.BlockchainHead, err
+			}
+			_ = n.Store.PutValidator(Validator)
+		}
 	}
 	return n.Blockchain, newBlock, nil
 }
 
+// ReceiveBlockCandidate validates a block proposed by someone else (e.g. a
+// peer's candidate for the current round, as opposed to one this node built
+// itself via GenerateNewBlock) and, on success, appends it exactly the same
+// way. Accepting an already-built candidate rather than constructing one for
+// "the" current round is what makes the double-sign check in
+// ValidateBlockCandidate reachable: two competing candidates for the same
+// round, from the same or different proposers, can both reach it before
+// either is committed, which a purely self-producing node never encounters.
+func (n PoSNetwork) ReceiveBlockCandidate(candidate *Block, proposer *Node) ([]*Block, *Block, error) {
+	if err := n.ValidateBlockCandidate(candidate); err != nil {
+		n.slash(proposer, classifyFault(err), candidate.Round)
+		return n.Blockchain, n.BlockchainHead, err
+	}
+
+	n.Blockchain = append(n.Blockchain, candidate)
+	proposer.LastStakeUseRound = candidate.Round
+	if n.UTXO != nil {
+		for _, tx := range candidate.Transactions {
+			n.UTXO.Apply(tx)
+		}
+	}
+	if n.Mempool != nil {
+		n.Mempool.Remove(candidate.Transactions)
+	}
+	if n.Store != nil {
+		if err := n.Store.CommitBlock(candidate); err != nil {
+			return n.Blockchain, n.BlockchainHead, err
+		}
+		_ = n.Store.PutValidator(proposer)
+	}
+	return n.Blockchain, candidate, nil
+}
+
+// drainMempool pulls up to Params.MaxTxPerBlock still-valid transactions out
+// of the mempool and returns them alongside the total reward (fees plus
+// Params.BlockReward) their producer should collect.
+func (n PoSNetwork) drainMempool() ([]*Transaction, int) {
+	reward := n.Params.BlockReward
+	if n.Mempool == nil || n.UTXO == nil {
+		return nil, reward
+	}
+
+	candidates := n.Mempool.Pull(n.Params.MaxTxPerBlock)
+	spent := make(map[TxHashPointer]bool)
+	var txs []*Transaction
+	for _, tx := range candidates {
+		fee, code := verifyTransaction(tx, n.UTXO, spent)
+		if code != ValidTransaction {
+			continue
+		}
+		for _, in := range tx.Inputs {
+			spent[in] = true
+		}
+		txs = append(txs, tx)
+		reward += fee
+	}
+	return txs, reward
+}
+
 func NewBlockHash(block *Block) string {
-	blockInfo := block.Timestamp + block.PrevHash + block.Hash + block.ValidatorAddr
+	blockInfo := block.Timestamp + block.PrevHash + block.Hash + block.ValidatorAddr + merkleRoot(block.Transactions)
 	return newHash(blockInfo)
 }
 
@@ -79,16 +238,34 @@ func newHash(s string) string {
 }
 
 func (n PoSNetwork) ValidateBlockchain() error {
-	if len(n.Blockchain) <= 1 {
+	// Prune drops older blocks from the in-memory cache, so once a Store is
+	// attached, validate against its full history instead: otherwise a
+	// pruned transaction's outputs vanish from the replay and every
+	// following GenerateNewBlock call fails (and slashes its producer) for
+	// no fault of its own.
+	chain := n.Blockchain
+	if n.Store != nil {
+		if head, err := n.Store.Head(); err == nil {
+			if full, err := n.chainFromStore(head); err == nil {
+				chain = full
+			}
+		}
+	}
+
+	if len(chain) <= 1 {
 		return nil
 	}
 
-	currBlockIdx := len(n.Blockchain) - 1
-	prevBlockIdx := len(n.Blockchain) - 2
+	if _, err := rebuildAndVerifyUTXO(chain); err != nil {
+		return err
+	}
+
+	currBlockIdx := len(chain) - 1
+	prevBlockIdx := len(chain) - 2
 
 	for prevBlockIdx >= 0 {
-		currBlock := n.Blockchain[currBlockIdx]
-		prevBlock := n.Blockchain[prevBlockIdx]
+		currBlock := chain[currBlockIdx]
+		prevBlock := chain[prevBlockIdx]
 		if currBlock.PrevHash != prevBlock.Hash {
 			return errors.New("blockchain has inconsistent hashes")
 		}
@@ -100,6 +277,17 @@ func (n PoSNetwork) ValidateBlockchain() error {
 		if NewBlockHash(prevBlock) != currBlock.Hash {
 			return errors.New("blockchain has inconsistent hash generation")
 		}
+
+		// Unlike the checks above, a block's beacon-selected winner cannot be
+		// re-derived here: eligiblePool depends on each validator's current
+		// LastStakeUseRound/JailedUntil, which have moved on since currBlock's
+		// round (winning resets the very age that round's draw depended on).
+		// Recomputing against today's state would pick a different winner
+		// than the one that was actually, correctly selected at the time and
+		// false-reject an honest chain. That check already happened once,
+		// against the right state, in ValidateBlockCandidate before the block
+		// was ever appended; it is not repeated here.
+
 		currBlockIdx--
 		prevBlockIdx--
 	}
@@ -109,8 +297,15 @@ func (n PoSNetwork) NewNode(stake int) []*Node {
 	newNode := &Node{
 		Stake:   stake,
 		Address: randAddress(),
+		// treat the node as last staked as of the current tip, so its age
+		// (and thus eligibility) starts accruing from the very next round
+		// rather than being stuck at zero for the round it joined in.
+		LastStakeUseRound: uint64(len(n.Blockchain) - 1),
 	}
 	n.Validators = append(n.Validators, newNode)
+	if n.Store != nil {
+		_ = n.Store.PutValidator(newNode)
+	}
 	return n.Validators
 }
 
@@ -120,39 +315,151 @@ func randAddress() string {
 	return fmt.Sprintf("%x", b)
 }
 
-func (n PoSNetwork) SelectWinner() (*Node, error) {
-	var winnerPool []*Node
-	totalStake := 0
+// eligiblePool returns the validators currently allowed to be drawn for
+// round (stake and coin age above Params.MinAge, and not jailed through
+// round), alongside their coin-age weights and the summed total weight.
+// Because age is derived from round rather than wall-clock time, any two
+// callers computing the pool for the same round against the same validator
+// set get byte-for-byte the same result.
+func (n PoSNetwork) eligiblePool(round uint64) ([]*Node, []int, int) {
+	params := n.Params
+	if params.MinAge == 0 && params.MaxAge == 0 {
+		params = DefaultPoSParams()
+	}
+	minAge := params.MinAge
+	maxAge := params.MaxAge
+
+	var pool []*Node
+	var weights []int
+	totalWeight := 0
 	for _, node := range n.Validators {
-		if node.Stake > 0 {
-			winnerPool = append(winnerPool, node)
-			totalStake += node.Stake
+		if node.Stake <= 0 {
+			continue
 		}
+		if node.JailedUntil > round {
+			continue
+		}
+		age := node.CoinAge(round)
+		if age < minAge {
+			continue
+		}
+		if age > maxAge {
+			age = maxAge
+		}
+		weight := node.Stake * age
+		if weight <= 0 {
+			continue
+		}
+		pool = append(pool, node)
+		weights = append(weights, weight)
+		totalWeight += weight
 	}
-	if winnerPool == nil {
-		return nil, errors.New("there are no nodes with stake in the network")
-	}
-	winnerNumber := math.Intn(totalStake)
+	return pool, weights, totalWeight
+}
+
+// drawWinner walks an eligible pool using winnerNumber, a value in
+// [0, totalWeight), and returns the node whose weighted range it falls in.
+func drawWinner(pool []*Node, weights []int, winnerNumber int) (*Node, error) {
 	tmp := 0
-	for _, node := range n.Validators {
-		tmp += node.Stake
+	for i, node := range pool {
+		tmp += weights[i]
 		if winnerNumber < tmp {
 			return node, nil
 		}
 	}
 	return nil, errors.New("a winner should have been picked but wasn't")
 }
+
+// SelectWinner performs a weighted draw over eligible validators, where the
+// weight of each is its stake multiplied by its coin age (capped at
+// Params.MaxAge). Validators younger than Params.MinAge are not eligible.
+// When Beacon is configured, the draw is derived from the beacon entry for
+// the upcoming round instead of math/rand, so every node fed the same
+// beacon reaches the same selection.
+func (n PoSNetwork) SelectWinner() (*Node, error) {
+	round := uint64(len(n.Blockchain))
+	pool, weights, totalWeight := n.eligiblePool(round)
+	if pool == nil {
+		return nil, errors.New("there are no eligible nodes with sufficient coin age in the network")
+	}
+
+	if n.Beacon != nil {
+		entry, err := n.Beacon.EntryAt(round)
+		if err != nil {
+			return nil, err
+		}
+		winnerNumber := int(drawSeed(entry, n.BlockchainHead.Hash, round) % uint64(totalWeight))
+		return drawWinner(pool, weights, winnerNumber)
+	}
+
+	winnerNumber := math.Intn(totalWeight)
+	return drawWinner(pool, weights, winnerNumber)
+}
+
+// expectedWinnerForRound recomputes, using the current validator set, which
+// node the beacon draw for round would select given prevHash as the chain
+// tip at that time. It is used to check a block's ValidatorAddr against the
+// beacon rather than trusting it blindly.
+func (n PoSNetwork) expectedWinnerForRound(round uint64, prevHash string) (*Node, error) {
+	if n.Beacon == nil {
+		return nil, errors.New("no randomness beacon configured")
+	}
+	pool, weights, totalWeight := n.eligiblePool(round)
+	if pool == nil {
+		return nil, errors.New("there are no eligible nodes with sufficient coin age in the network")
+	}
+	entry, err := n.Beacon.EntryAt(round)
+	if err != nil {
+		return nil, err
+	}
+	winnerNumber := int(drawSeed(entry, prevHash, round) % uint64(totalWeight))
+	return drawWinner(pool, weights, winnerNumber)
+}
+
+// ValidateBlockCandidate checks newBlock against chain continuity, the hash
+// target, and (if configured) the randomness beacon, recording the
+// candidate's (ValidatorAddr, Round) -> Hash pair as it passes so a later
+// candidate reusing the same round with a different hash is caught as
+// double-signing. Recording here rather than only on commit is what makes
+// that check reachable at all: a validator's first proposal for a round
+// would otherwise only ever be indexed after it wins and is appended, by
+// which point the round has already advanced and can never recur.
 func (n PoSNetwork) ValidateBlockCandidate(newBlock *Block) error {
+	if existingHash, signed := n.SignRecords[signKey{newBlock.ValidatorAddr, newBlock.Round}]; signed && existingHash != newBlock.Hash {
+		return faultf(FaultDoubleSign, "validator %s already signed a different block at round %d", newBlock.ValidatorAddr, newBlock.Round)
+	}
+
 	if n.BlockchainHead.Hash != newBlock.PrevHash {
-		return errors.New("blockchain HEAD hash is not equal to new block previous hash")
+		return faultf(FaultInvalidHash, "blockchain HEAD hash is not equal to new block previous hash")
 	}
 
 	if n.BlockchainHead.Timestamp >= newBlock.Timestamp {
-		return errors.New("blockchain HEAD timestamp is greater than or equal to new block timestamp")
+		return faultf(FaultStaleTimestamp, "blockchain HEAD timestamp is greater than or equal to new block timestamp")
+	}
+
+	if n.hashTarget()(n.BlockchainHead) != newBlock.Hash {
+		return faultf(FaultInvalidHash, "new block hash of blockchain HEAD does not equal new block hash")
+	}
+
+	if n.Beacon != nil {
+		entry, err := n.Beacon.EntryAt(newBlock.Round)
+		if err != nil {
+			return faultf(FaultUnavailable, "%s", err)
+		}
+		if !bytes.Equal(entry, newBlock.BeaconEntry) {
+			return faultf(FaultInvalidHash, "block beacon entry does not match the beacon for its round")
+		}
+		expected, err := n.expectedWinnerForRound(newBlock.Round, newBlock.PrevHash)
+		if err != nil {
+			return faultf(FaultUnavailable, "%s", err)
+		}
+		if expected.Address != newBlock.ValidatorAddr {
+			return faultf(FaultInvalidHash, "block validator %s does not match the beacon-selected winner %s for round %d", newBlock.ValidatorAddr, expected.Address, newBlock.Round)
+		}
 	}
 
-	if NewBlockHash(n.BlockchainHead) != newBlock.Hash {
-		return errors.New("new block hash of blockchain HEAD does not equal new block hash")
+	if n.SignRecords != nil {
+		n.SignRecords[signKey{newBlock.ValidatorAddr, newBlock.Round}] = newBlock.Hash
 	}
 	return nil
 }
@@ -175,6 +482,15 @@ func main() {
 	}
 	pos.BlockchainHead = pos.Blockchain[0]
 
+	// shrink the coin-age bounds so the demo below produces eligible
+	// winners right away. Coin age is measured in rounds, not wall-clock
+	// time, so no sleep is needed between them.
+	pos.Params = PoSParams{
+		MinAge:     0,
+		MaxAge:     10,
+		HashTarget: NewBlockHash,
+	}
+
 	// instantiate nodes to act as validators in our network
 	pos.Validators = pos.NewNode(60)
 	pos.Validators = pos.NewNode(40)
@@ -185,11 +501,11 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-		winner.Stake += 10
 		pos.Blockchain, pos.BlockchainHead, err = pos.GenerateNewBlock(winner)
 		if err != nil {
 			log.Fatal(err)
 		}
+		winner.Stake += 10
 		fmt.Println("Round ", i)
 		fmt.Println("\tAddress:", pos.Validators[0].Address, "-Stake:", pos.Validators[0].Stake)
 		fmt.Println("\tAddress:", pos.Validators[1].Address, "-Stake:", pos.Validators[1].Stake)
@@ -0,0 +1,105 @@
+package main
+
+import "testing"
+
+func TestFreshNodeIneligibleUntilMinAge(t *testing.T) {
+	pos := &PoSNetwork{
+		Blockchain: []*Block{{Hash: "genesis"}},
+		Params:     PoSParams{MinAge: 3, MaxAge: 100},
+	}
+	pos.BlockchainHead = pos.Blockchain[0]
+	pos.Validators = pos.NewNode(50)
+	node := pos.Validators[0]
+
+	for round := uint64(1); round < 3; round++ {
+		if pool, _, _ := pos.eligiblePool(round); len(pool) != 0 {
+			t.Fatalf("round %d: expected node ineligible (age %d < MinAge), got eligible", round, node.CoinAge(round))
+		}
+	}
+
+	if pool, _, _ := pos.eligiblePool(3); len(pool) != 1 {
+		t.Fatalf("round 3: expected node eligible once age reaches MinAge, got pool of %d", len(pool))
+	}
+}
+
+func TestWinningResetsCoinAge(t *testing.T) {
+	pos := &PoSNetwork{
+		Blockchain: []*Block{{Hash: "genesis"}},
+		Params:     PoSParams{MinAge: 0, MaxAge: 100},
+	}
+	pos.BlockchainHead = pos.Blockchain[0]
+	pos.Validators = pos.NewNode(50)
+	node := pos.Validators[0]
+
+	var err error
+	pos.Blockchain, pos.BlockchainHead, err = pos.GenerateNewBlock(node)
+	if err != nil {
+		t.Fatalf("GenerateNewBlock: %v", err)
+	}
+	if age := node.CoinAge(pos.BlockchainHead.Round); age != 0 {
+		t.Fatalf("expected coin age reset to 0 right after winning, got %d", age)
+	}
+}
+
+func TestTwoNetworksAgreeGivenSameBeacon(t *testing.T) {
+	build := func(beacon RandomnessBeacon) *PoSNetwork {
+		pos := &PoSNetwork{
+			Blockchain: []*Block{{Hash: "genesis"}},
+			Params:     PoSParams{MinAge: 0, MaxAge: 100},
+			Beacon:     beacon,
+			Validators: []*Node{
+				{Stake: 60, Address: "validator-a"},
+				{Stake: 40, Address: "validator-b"},
+			},
+		}
+		pos.BlockchainHead = pos.Blockchain[0]
+		return pos
+	}
+
+	seed := []byte("shared-seed")
+	net1 := build(NewMockBeacon(seed))
+	net2 := build(NewMockBeacon(seed))
+
+	w1, err := net1.SelectWinner()
+	if err != nil {
+		t.Fatalf("net1 SelectWinner: %v", err)
+	}
+	w2, err := net2.SelectWinner()
+	if err != nil {
+		t.Fatalf("net2 SelectWinner: %v", err)
+	}
+	if w1.Address != w2.Address {
+		t.Fatalf("expected identical winners from the same beacon, got %s vs %s", w1.Address, w2.Address)
+	}
+}
+
+// TestBeaconChainValidatesAcrossMultipleRounds guards against a regression
+// where ValidateBlockchain re-derived each historical block's beacon winner
+// against the *current* validator set. Winning resets a validator's coin
+// age, so that re-derivation would pick a different winner than the one
+// correctly selected at the time, false-rejecting the very next block and
+// slashing its producer. A beacon-driven chain must be able to grow past
+// round 0.
+func TestBeaconChainValidatesAcrossMultipleRounds(t *testing.T) {
+	pos := &PoSNetwork{
+		Blockchain: []*Block{{Hash: "genesis"}},
+		Params:     PoSParams{MinAge: 0, MaxAge: 100},
+		Beacon:     NewMockBeacon([]byte("shared-seed")),
+		Validators: []*Node{
+			{Stake: 60, Address: "validator-a"},
+			{Stake: 40, Address: "validator-b"},
+		},
+	}
+	pos.BlockchainHead = pos.Blockchain[0]
+
+	for round := 0; round < 4; round++ {
+		winner, err := pos.SelectWinner()
+		if err != nil {
+			t.Fatalf("round %d: SelectWinner: %v", round, err)
+		}
+		pos.Blockchain, pos.BlockchainHead, err = pos.GenerateNewBlock(winner)
+		if err != nil {
+			t.Fatalf("round %d: GenerateNewBlock: %v", round, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server exposes a PoSNetwork over HTTP. It owns the only lock that
+// serializes access to Network: PoSNetwork's methods use value receivers
+// (see GenerateNewBlock, NewNode, ...), so embedding the lock inside
+// PoSNetwork itself would hand every call its own copy of it instead of a
+// shared one. Guarding calls from here keeps that existing method style
+// intact while still making concurrent HTTP handlers safe.
+type Server struct {
+	mu      sync.RWMutex
+	Network *PoSNetwork
+
+	subMu       sync.Mutex
+	subscribers map[chan *Block]struct{}
+}
+
+// NewServer wraps network for HTTP access.
+func NewServer(network *PoSNetwork) *Server {
+	return &Server{
+		Network:     network,
+		subscribers: make(map[chan *Block]struct{}),
+	}
+}
+
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks", s.handleBlocks)
+	mux.HandleFunc("/blocks/subscribe", s.handleBlocksSubscribe)
+	mux.HandleFunc("/blocks/", s.handleBlockByIndex)
+	mux.HandleFunc("/head", s.handleHead)
+	mux.HandleFunc("/validators", s.handleValidators)
+	mux.HandleFunc("/mine", s.handleMine)
+	mux.HandleFunc("/blocks/propose", s.handleBlockPropose)
+	mux.HandleFunc("/winner/next", s.handleWinnerNext)
+	return mux
+}
+
+// ListenAndServe starts the HTTP server on PORT (default 9000).
+func (s *Server) ListenAndServe() error {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "9000"
+	}
+	return http.ListenAndServe(":"+port, s.routes())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, s.Network.Blockchain)
+}
+
+func (s *Server) handleBlockByIndex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	idxStr := strings.TrimPrefix(r.URL.Path, "/blocks/")
+	index, err := strconv.Atoi(idxStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid block index %q", idxStr))
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if index < 0 || index >= len(s.Network.Blockchain) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no block at index %d", index))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.Network.Blockchain[index])
+}
+
+func (s *Server) handleHead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, s.Network.BlockchainHead)
+}
+
+func (s *Server) handleValidators(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		writeJSON(w, http.StatusOK, s.Network.Validators)
+	case http.MethodPost:
+		var body struct {
+			Stake int `json:"stake"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.Network.Validators = s.Network.NewNode(body.Stake)
+		writeJSON(w, http.StatusCreated, s.Network.Validators[len(s.Network.Validators)-1])
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleMine(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	winner, err := s.Network.SelectWinner()
+	if err != nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	s.Network.Blockchain, s.Network.BlockchainHead, err = s.Network.GenerateNewBlock(winner)
+	newHead := s.Network.BlockchainHead
+	s.mu.Unlock()
+
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	s.publish(newHead)
+	writeJSON(w, http.StatusOK, newHead)
+}
+
+// handleBlockPropose accepts a block candidate from a peer, as opposed to
+// /mine which produces one locally, and runs it through the same
+// validate-then-append path (ReceiveBlockCandidate). This is what lets a
+// proposer who equivocates on a round it already signed actually be caught:
+// /mine alone can never feed ValidateBlockCandidate two competing blocks for
+// the same round.
+func (s *Server) handleBlockPropose(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Block     *Block `json:"block"`
+		Validator string `json:"validator"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var proposer *Node
+	for _, v := range s.Network.Validators {
+		if v.Address == body.Validator {
+			proposer = v
+			break
+		}
+	}
+	if proposer == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown validator %q", body.Validator))
+		return
+	}
+
+	var err error
+	s.Network.Blockchain, s.Network.BlockchainHead, err = s.Network.ReceiveBlockCandidate(body.Block, proposer)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	s.publish(s.Network.BlockchainHead)
+	writeJSON(w, http.StatusOK, s.Network.BlockchainHead)
+}
+
+func (s *Server) handleWinnerNext(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.RLock()
+	winner, err := s.Network.SelectWinner()
+	s.mu.RUnlock()
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, winner)
+}
+
+// handleBlocksSubscribe streams newly produced blocks as server-sent events.
+func (s *Server) handleBlocksSubscribe(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan *Block, 16)
+	s.subMu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.subMu.Unlock()
+	defer func() {
+		s.subMu.Lock()
+		delete(s.subscribers, ch)
+		s.subMu.Unlock()
+	}()
+
+	for {
+		select {
+		case block := <-ch:
+			data, err := json.Marshal(block)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) publish(block *Block) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- block:
+		default:
+		}
+	}
+}
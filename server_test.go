@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTwoNodesAgreeViaAPI(t *testing.T) {
+	build := func() *Server {
+		pos := &PoSNetwork{
+			Blockchain: []*Block{{Hash: "genesis"}},
+			Params:     PoSParams{MinAge: 0, MaxAge: 100},
+			Beacon:     NewMockBeacon([]byte("shared-seed")),
+			Validators: []*Node{
+				{Stake: 60, Address: "validator-a"},
+				{Stake: 40, Address: "validator-b"},
+			},
+		}
+		pos.BlockchainHead = pos.Blockchain[0]
+		return NewServer(pos)
+	}
+
+	ts1 := httptest.NewServer(build().routes())
+	defer ts1.Close()
+	ts2 := httptest.NewServer(build().routes())
+	defer ts2.Close()
+
+	for _, ts := range []*httptest.Server{ts1, ts2} {
+		resp, err := http.Post(ts.URL+"/mine", "application/json", nil)
+		if err != nil {
+			t.Fatalf("POST /mine: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("POST /mine: status %d", resp.StatusCode)
+		}
+		resp.Body.Close()
+	}
+
+	head1 := fetchHead(t, ts1.URL)
+	head2 := fetchHead(t, ts2.URL)
+
+	if head1.ValidatorAddr != head2.ValidatorAddr || head1.Round != head2.Round {
+		t.Fatalf("nodes disagree on head reached via their APIs: %+v vs %+v", head1, head2)
+	}
+}
+
+func fetchHead(t *testing.T, baseURL string) Block {
+	t.Helper()
+	resp, err := http.Get(baseURL + "/head")
+	if err != nil {
+		t.Fatalf("GET /head: %v", err)
+	}
+	defer resp.Body.Close()
+	var block Block
+	if err := json.NewDecoder(resp.Body).Decode(&block); err != nil {
+		t.Fatalf("decode head: %v", err)
+	}
+	return block
+}
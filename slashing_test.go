@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlashSeverityByFault(t *testing.T) {
+	light := &Node{Stake: 100, Address: "light"}
+	heavy := &Node{Stake: 100, Address: "heavy"}
+	pos := PoSNetwork{}
+
+	pos.slash(light, FaultInvalidHash, 5)
+	if light.Stake != 90 {
+		t.Fatalf("expected a light -10 slash, got stake %d", light.Stake)
+	}
+	if light.JailedUntil != 0 {
+		t.Fatalf("expected no jail for a light fault, got JailedUntil=%d", light.JailedUntil)
+	}
+
+	pos.slash(heavy, FaultDoubleSign, 5)
+	if heavy.Stake != 70 {
+		t.Fatalf("expected a heavy 30%% slash, got stake %d", heavy.Stake)
+	}
+	if heavy.JailedUntil != 15 {
+		t.Fatalf("expected jail until round+10=15, got %d", heavy.JailedUntil)
+	}
+}
+
+func TestJailedValidatorSkippedBySelectWinner(t *testing.T) {
+	jailed := &Node{Stake: 100, Address: "jailed", JailedUntil: 10}
+	free := &Node{Stake: 1, Address: "free"}
+	pos := &PoSNetwork{
+		Blockchain: []*Block{{Hash: "genesis"}},
+		Params:     PoSParams{MinAge: 0, MaxAge: 100},
+		Validators: []*Node{jailed, free},
+	}
+	pos.BlockchainHead = pos.Blockchain[0]
+
+	winner, err := pos.SelectWinner()
+	if err != nil {
+		t.Fatalf("SelectWinner: %v", err)
+	}
+	if winner.Address != "free" {
+		t.Fatalf("expected the jailed validator to be skipped despite its larger stake, got winner %s", winner.Address)
+	}
+}
+
+// TestDoubleSignDetectedAcrossCompetingCandidates drives two distinct-hash
+// candidates for the same (ValidatorAddr, Round) through
+// ValidateBlockCandidate via ReceiveBlockCandidate, the path a peer's
+// proposal actually takes. GenerateNewBlock alone can never reproduce this:
+// its round always advances past a block it commits, so the same round
+// never recurs for it to equivocate on.
+func TestDoubleSignDetectedAcrossCompetingCandidates(t *testing.T) {
+	genesis := &Block{Hash: "genesis"}
+	pos := &PoSNetwork{
+		Blockchain:  []*Block{genesis},
+		Params:      PoSParams{MinAge: 0, MaxAge: 100},
+		SignRecords: make(map[signKey]string),
+	}
+	pos.BlockchainHead = genesis
+	validator := &Node{Stake: 100, Address: "v1"}
+
+	blockA := &Block{
+		Timestamp:     time.Now().Add(1 * time.Second).String(),
+		PrevHash:      genesis.Hash,
+		Hash:          NewBlockHash(genesis),
+		ValidatorAddr: validator.Address,
+		Round:         1,
+	}
+	if _, _, err := pos.ReceiveBlockCandidate(blockA, validator); err != nil {
+		t.Fatalf("first candidate: %v", err)
+	}
+
+	// validator equivocates: a second, different block proposed for the
+	// round it already signed.
+	blockB := &Block{
+		Timestamp:     time.Now().Add(2 * time.Second).String(),
+		PrevHash:      genesis.Hash,
+		Hash:          "conflicting-hash",
+		ValidatorAddr: validator.Address,
+		Round:         1,
+	}
+	_, _, err := pos.ReceiveBlockCandidate(blockB, validator)
+	if err == nil {
+		t.Fatal("expected the conflicting candidate to be rejected as a double-sign")
+	}
+	if classifyFault(err) != FaultDoubleSign {
+		t.Fatalf("expected FaultDoubleSign, got %v", classifyFault(err))
+	}
+	if validator.Stake != 70 {
+		t.Fatalf("expected a heavy 30%% slash, got stake %d", validator.Stake)
+	}
+	if validator.JailedUntil == 0 {
+		t.Fatal("expected the validator to be jailed")
+	}
+}
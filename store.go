@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Store persists a PoSNetwork's chain and validator set so it survives a
+// restart; PoSNetwork.Blockchain/Validators become a cache over whichever
+// Store is attached.
+type Store interface {
+	PutBlock(block *Block) error
+	GetBlock(hash string) (*Block, error)
+	PutHead(hash string) error
+	Head() (string, error)
+	IterateValidators(fn func(*Node) error) error
+	PutValidator(node *Node) error
+	// CommitBlock writes block and advances the head to it as a single
+	// atomic operation, so a crash never leaves one without the other.
+	CommitBlock(block *Block) error
+}
+
+// MemoryStore is an in-memory Store, useful for tests.
+type MemoryStore struct {
+	mu         sync.Mutex
+	blocks     map[string]*Block
+	validators map[string]*Node
+	head       string
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		blocks:     make(map[string]*Block),
+		validators: make(map[string]*Node),
+	}
+}
+
+func (s *MemoryStore) PutBlock(block *Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[block.Hash] = block
+	return nil
+}
+
+func (s *MemoryStore) GetBlock(hash string) (*Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	block, ok := s.blocks[hash]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", hash)
+	}
+	return block, nil
+}
+
+func (s *MemoryStore) PutHead(hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.head = hash
+	return nil
+}
+
+func (s *MemoryStore) Head() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.head == "" {
+		return "", errors.New("no head set")
+	}
+	return s.head, nil
+}
+
+func (s *MemoryStore) PutValidator(node *Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validators[node.Address] = node
+	return nil
+}
+
+func (s *MemoryStore) IterateValidators(fn func(*Node) error) error {
+	s.mu.Lock()
+	nodes := make([]*Node, 0, len(s.validators))
+	for _, node := range s.validators {
+		nodes = append(nodes, node)
+	}
+	s.mu.Unlock()
+	for _, node := range nodes {
+		if err := fn(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CommitBlock(block *Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks[block.Hash] = block
+	s.head = block.Hash
+	return nil
+}
+
+// LevelDBStore is a Store backed by an embedded LevelDB instance.
+type LevelDBStore struct {
+	db *leveldb.DB
+}
+
+var (
+	headKey         = []byte("head")
+	validatorPrefix = []byte("v:")
+)
+
+func blockKey(hash string) []byte {
+	return []byte("b:" + hash)
+}
+
+func validatorKey(address string) []byte {
+	return append(append([]byte{}, validatorPrefix...), []byte(address)...)
+}
+
+// OpenLevelDBStore opens (creating if necessary) a LevelDB database at path.
+func OpenLevelDBStore(path string) (*LevelDBStore, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("opening leveldb at %s: %w", path, err)
+	}
+	return &LevelDBStore{db: db}, nil
+}
+
+func (s *LevelDBStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *LevelDBStore) PutBlock(block *Block) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(blockKey(block.Hash), data, nil)
+}
+
+func (s *LevelDBStore) GetBlock(hash string) (*Block, error) {
+	data, err := s.db.Get(blockKey(hash), nil)
+	if err != nil {
+		return nil, fmt.Errorf("block %s not found: %w", hash, err)
+	}
+	var block Block
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+func (s *LevelDBStore) PutHead(hash string) error {
+	return s.db.Put(headKey, []byte(hash), nil)
+}
+
+func (s *LevelDBStore) Head() (string, error) {
+	data, err := s.db.Get(headKey, nil)
+	if err != nil {
+		return "", fmt.Errorf("no head set: %w", err)
+	}
+	return string(data), nil
+}
+
+func (s *LevelDBStore) PutValidator(node *Node) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(validatorKey(node.Address), data, nil)
+}
+
+func (s *LevelDBStore) IterateValidators(fn func(*Node) error) error {
+	iter := s.db.NewIterator(util.BytesPrefix(validatorPrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var node Node
+		if err := json.Unmarshal(iter.Value(), &node); err != nil {
+			return err
+		}
+		if err := fn(&node); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func (s *LevelDBStore) CommitBlock(block *Block) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	batch.Put(blockKey(block.Hash), data)
+	batch.Put(headKey, []byte(block.Hash))
+	return s.db.Write(batch, nil)
+}
+
+// chainFromStore walks back from head, reconstructing the full chain from
+// Store. Unlike n.Blockchain, this is unaffected by Prune, so callers that
+// need the complete history (Open, ValidateBlockchain) use it instead of the
+// in-memory cache.
+func (n PoSNetwork) chainFromStore(head string) ([]*Block, error) {
+	var blocks []*Block
+	seen := make(map[string]bool)
+	for hash := head; hash != "" && !seen[hash]; {
+		seen[hash] = true
+		block, err := n.Store.GetBlock(hash)
+		if err != nil {
+			return nil, fmt.Errorf("reconstructing blockchain: %w", err)
+		}
+		blocks = append([]*Block{block}, blocks...)
+		hash = block.PrevHash
+	}
+	return blocks, nil
+}
+
+// Open attaches a LevelDBStore at path to the network and, if it already
+// holds a chain, reconstructs Blockchain, BlockchainHead and Validators from
+// it before validating the result.
+func (n *PoSNetwork) Open(path string) error {
+	store, err := OpenLevelDBStore(path)
+	if err != nil {
+		return err
+	}
+	n.Store = store
+
+	head, err := store.Head()
+	if err != nil {
+		// fresh store: nothing on disk to reconstruct yet.
+		return nil
+	}
+
+	blocks, err := n.chainFromStore(head)
+	if err != nil {
+		return err
+	}
+	n.Blockchain = blocks
+	if len(blocks) > 0 {
+		n.BlockchainHead = blocks[len(blocks)-1]
+	}
+
+	var validators []*Node
+	if err := store.IterateValidators(func(node *Node) error {
+		validators = append(validators, node)
+		return nil
+	}); err != nil {
+		return err
+	}
+	n.Validators = validators
+
+	if err := n.ValidateBlockchain(); err != nil {
+		return fmt.Errorf("restored chain failed validation: %w", err)
+	}
+	return nil
+}
+
+// Close releases the attached Store, if any.
+func (n *PoSNetwork) Close() error {
+	if n.Store == nil {
+		return nil
+	}
+	closer, ok := n.Store.(*LevelDBStore)
+	if !ok {
+		n.Store = nil
+		return nil
+	}
+	err := closer.Close()
+	n.Store = nil
+	return err
+}
+
+// Prune drops all but the most recent keepLastN blocks from the in-memory
+// cache; the full history remains retrievable from Store.
+func (n *PoSNetwork) Prune(keepLastN int) {
+	if keepLastN <= 0 || len(n.Blockchain) <= keepLastN {
+		return
+	}
+	n.Blockchain = append([]*Block{}, n.Blockchain[len(n.Blockchain)-keepLastN:]...)
+}
+
+// AddOrphan records a block whose parent hasn't been seen yet, keyed by the
+// hash it expects as its predecessor.
+func (n *PoSNetwork) AddOrphan(block *Block) {
+	if n.Orphans == nil {
+		n.Orphans = make(map[string][]*Block)
+	}
+	n.Orphans[block.PrevHash] = append(n.Orphans[block.PrevHash], block)
+}
+
+// ReconnectOrphans repeatedly extends the chain with any orphaned block
+// whose PrevHash now matches the head, e.g. after a late-arriving parent is
+// accepted. When multiple orphans compete for the same slot, the first one
+// recorded wins and the rest are discarded.
+func (n *PoSNetwork) ReconnectOrphans() {
+	for {
+		children := n.Orphans[n.BlockchainHead.Hash]
+		if len(children) == 0 {
+			return
+		}
+		next := children[0]
+		delete(n.Orphans, n.BlockchainHead.Hash)
+
+		n.Blockchain = append(n.Blockchain, next)
+		n.BlockchainHead = next
+		if n.Store != nil {
+			_ = n.Store.CommitBlock(next)
+		}
+	}
+}
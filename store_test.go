@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestMemoryStoreRoundTrip(t *testing.T) {
+	s := NewMemoryStore()
+
+	block := &Block{Hash: "h1", PrevHash: "genesis"}
+	if err := s.PutBlock(block); err != nil {
+		t.Fatalf("PutBlock: %v", err)
+	}
+	got, err := s.GetBlock("h1")
+	if err != nil {
+		t.Fatalf("GetBlock: %v", err)
+	}
+	if got.PrevHash != "genesis" {
+		t.Fatalf("unexpected block: %+v", got)
+	}
+
+	if _, err := s.Head(); err == nil {
+		t.Fatal("expected an error before any head is set")
+	}
+	if err := s.PutHead("h1"); err != nil {
+		t.Fatalf("PutHead: %v", err)
+	}
+	if head, err := s.Head(); err != nil || head != "h1" {
+		t.Fatalf("Head() = %q, %v", head, err)
+	}
+
+	node := &Node{Address: "alice", Stake: 50}
+	if err := s.PutValidator(node); err != nil {
+		t.Fatalf("PutValidator: %v", err)
+	}
+	var seen []*Node
+	if err := s.IterateValidators(func(n *Node) error {
+		seen = append(seen, n)
+		return nil
+	}); err != nil {
+		t.Fatalf("IterateValidators: %v", err)
+	}
+	if len(seen) != 1 || seen[0].Address != "alice" || seen[0].Stake != 50 {
+		t.Fatalf("unexpected validators: %+v", seen)
+	}
+
+	block2 := &Block{Hash: "h2", PrevHash: "h1"}
+	if err := s.CommitBlock(block2); err != nil {
+		t.Fatalf("CommitBlock: %v", err)
+	}
+	if head, err := s.Head(); err != nil || head != "h2" {
+		t.Fatalf("Head() after CommitBlock = %q, %v", head, err)
+	}
+	if _, err := s.GetBlock("h2"); err != nil {
+		t.Fatalf("GetBlock(h2): %v", err)
+	}
+}
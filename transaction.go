@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+)
+
+// TxHashPointer references a single output of a previous transaction,
+// analogous to a UTXO outpoint.
+type TxHashPointer struct {
+	TxHash string
+	Index  int
+}
+
+// TxOutput pays Amount to Recipient.
+type TxOutput struct {
+	Recipient string
+	Amount    int
+}
+
+// Transaction spends the outputs referenced by Inputs and creates Outputs.
+// Signature is a commitment over the transaction body keyed by Sender; see
+// Sign and verifySignature.
+type Transaction struct {
+	Sender    string
+	Inputs    []TxHashPointer
+	Outputs   []TxOutput
+	Signature []byte
+}
+
+// NewCoinbaseTx mints amount to recipient out of nothing, the way a block
+// producer is credited its reward: it carries no Inputs, since there is no
+// existing output being spent. Mempool.Push refuses transactions shaped this
+// way from outside, so the only coinbase a block ever contains is the one
+// GenerateNewBlock builds for its own producer.
+func NewCoinbaseTx(recipient string, amount int) *Transaction {
+	return &Transaction{Outputs: []TxOutput{{Recipient: recipient, Amount: amount}}}
+}
+
+// isCoinbase reports whether tx mints value rather than spending an
+// existing output.
+func (tx *Transaction) isCoinbase() bool {
+	return len(tx.Inputs) == 0
+}
+
+// body serializes the fields a signature and hash must cover.
+func (tx *Transaction) body() string {
+	s := tx.Sender
+	for _, in := range tx.Inputs {
+		s += fmt.Sprintf("%s:%d", in.TxHash, in.Index)
+	}
+	for _, out := range tx.Outputs {
+		s += fmt.Sprintf("%s:%d", out.Recipient, out.Amount)
+	}
+	return s
+}
+
+// Hash identifies a Transaction independent of its signature, so it can be
+// referenced by TxHashPointer before or after signing.
+func (tx *Transaction) Hash() string {
+	return newHash(tx.body())
+}
+
+// Sign stamps the transaction with a commitment derived from secret. There
+// is no real asymmetric cryptography here, consistent with the rest of this
+// package's use of sha256 as a stand-in primitive, and verifyTransaction
+// checks it against tx.Sender itself rather than a key only the real sender
+// would hold — so, unlike a real signature, this does not authenticate
+// against forgery: anyone who can read Sender off the transaction can
+// reproduce it. It exists to give the mempool/block-candidate paths a
+// tamper-evidence check (catching a mismatched or corrupted body) pending a
+// real key-based scheme, not to stand in for one.
+func (tx *Transaction) Sign(secret string) {
+	tx.Signature = []byte(newHash(secret + tx.body()))
+}
+
+func (tx *Transaction) verifySignature(secret string) bool {
+	return string(tx.Signature) == newHash(secret+tx.body())
+}
+
+// UTXOSet tracks unspent transaction outputs keyed by the outpoint that
+// created them.
+type UTXOSet map[TxHashPointer]TxOutput
+
+// Apply removes the outputs tx spends and records the ones it creates. The
+// caller must have already validated tx against this same set.
+func (u UTXOSet) Apply(tx *Transaction) {
+	for _, in := range tx.Inputs {
+		delete(u, in)
+	}
+	txHash := tx.Hash()
+	for i, out := range tx.Outputs {
+		u[TxHashPointer{TxHash: txHash, Index: i}] = out
+	}
+}
+
+// PushCode classifies the outcome of submitting a Transaction to a Mempool
+// or block, mirroring the typed-result pattern used by mature chains
+// instead of a bare boolean.
+type PushCode int
+
+const (
+	ValidTransaction PushCode = iota
+	DoubleSpend
+	BadSignature
+	InsufficientFunds
+	AlreadyInPool
+)
+
+func (c PushCode) String() string {
+	switch c {
+	case ValidTransaction:
+		return "ValidTransaction"
+	case DoubleSpend:
+		return "DoubleSpend"
+	case BadSignature:
+		return "BadSignature"
+	case InsufficientFunds:
+		return "InsufficientFunds"
+	case AlreadyInPool:
+		return "AlreadyInPool"
+	default:
+		return "Unknown"
+	}
+}
+
+// verifyTransaction checks tx's signature and that its inputs are unspent in
+// utxo (and not already claimed by spent, used to catch double-spends across
+// transactions sharing a mempool or block). It returns the net fee (inputs
+// minus outputs) on success.
+//
+// The signature is checked against tx.Sender, a public field: see Sign's
+// doc comment for why this rejects a malformed/corrupted body rather than an
+// unauthorized one.
+func verifyTransaction(tx *Transaction, utxo UTXOSet, spent map[TxHashPointer]bool) (int, PushCode) {
+	if tx.isCoinbase() {
+		return 0, ValidTransaction
+	}
+
+	if !tx.verifySignature(tx.Sender) {
+		return 0, BadSignature
+	}
+
+	total := 0
+	for _, in := range tx.Inputs {
+		if spent != nil && spent[in] {
+			return 0, DoubleSpend
+		}
+		out, ok := utxo[in]
+		if !ok {
+			return 0, DoubleSpend
+		}
+		total += out.Amount
+	}
+
+	need := 0
+	for _, out := range tx.Outputs {
+		need += out.Amount
+	}
+	if total < need {
+		return 0, InsufficientFunds
+	}
+	return total - need, ValidTransaction
+}
+
+// Mempool holds transactions that have passed validation but are not yet
+// included in a block.
+type Mempool struct {
+	pending map[string]*Transaction
+	order   []string
+}
+
+// NewMempool returns an empty Mempool.
+func NewMempool() *Mempool {
+	return &Mempool{pending: make(map[string]*Transaction)}
+}
+
+// Push validates tx against utxo and the mempool's own pending transactions,
+// admitting it on success.
+func (m *Mempool) Push(tx *Transaction, utxo UTXOSet) PushCode {
+	if tx.isCoinbase() {
+		// an input-less transaction mints value out of nothing; only
+		// GenerateNewBlock's own reward transaction may do that, and it
+		// never goes through the mempool.
+		return BadSignature
+	}
+
+	hash := tx.Hash()
+	if _, ok := m.pending[hash]; ok {
+		return AlreadyInPool
+	}
+
+	spent := make(map[TxHashPointer]bool)
+	for _, pending := range m.pending {
+		for _, in := range pending.Inputs {
+			spent[in] = true
+		}
+	}
+
+	if _, code := verifyTransaction(tx, utxo, spent); code != ValidTransaction {
+		return code
+	}
+
+	m.pending[hash] = tx
+	m.order = append(m.order, hash)
+	return ValidTransaction
+}
+
+// Pull returns up to maxN pending transactions in admission order without
+// removing them; call Remove once they are committed to a block.
+func (m *Mempool) Pull(maxN int) []*Transaction {
+	n := maxN
+	if n > len(m.order) {
+		n = len(m.order)
+	}
+	out := make([]*Transaction, n)
+	for i := 0; i < n; i++ {
+		out[i] = m.pending[m.order[i]]
+	}
+	return out
+}
+
+// Remove drops the given transactions from the pool, e.g. after they are
+// included in a block.
+func (m *Mempool) Remove(txs []*Transaction) {
+	for _, tx := range txs {
+		delete(m.pending, tx.Hash())
+	}
+	order := m.order[:0]
+	for _, hash := range m.order {
+		if _, ok := m.pending[hash]; ok {
+			order = append(order, hash)
+		}
+	}
+	m.order = order
+}
+
+// merkleRoot folds a list of transactions into a single root hash, binary
+// tree style, duplicating the last node at each level when the count is odd.
+func merkleRoot(txs []*Transaction) string {
+	if len(txs) == 0 {
+		return ""
+	}
+	layer := make([]string, len(txs))
+	for i, tx := range txs {
+		layer[i] = tx.Hash()
+	}
+	for len(layer) > 1 {
+		var next []string
+		for i := 0; i < len(layer); i += 2 {
+			if i+1 < len(layer) {
+				next = append(next, newHash(layer[i]+layer[i+1]))
+			} else {
+				next = append(next, newHash(layer[i]+layer[i]))
+			}
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+// rebuildAndVerifyUTXO replays every transaction in the chain from genesis,
+// verifying each against the UTXO set built from everything before it.
+func rebuildAndVerifyUTXO(blockchain []*Block) (UTXOSet, error) {
+	utxo := make(UTXOSet)
+	for _, block := range blockchain {
+		// transactions within a block must not double-spend each other
+		spent := make(map[TxHashPointer]bool)
+		for _, tx := range block.Transactions {
+			if _, code := verifyTransaction(tx, utxo, spent); code != ValidTransaction {
+				return nil, fmt.Errorf("transaction %s failed verification: %s", tx.Hash(), code)
+			}
+			for _, in := range tx.Inputs {
+				spent[in] = true
+			}
+			utxo.Apply(tx)
+		}
+	}
+	return utxo, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestMempoolPushCodes(t *testing.T) {
+	utxo := make(UTXOSet)
+	utxo[TxHashPointer{TxHash: "seed", Index: 0}] = TxOutput{Recipient: "alice", Amount: 100}
+
+	validTx := &Transaction{Sender: "alice", Inputs: []TxHashPointer{{TxHash: "seed", Index: 0}}, Outputs: []TxOutput{{Recipient: "bob", Amount: 40}}}
+	validTx.Sign("alice")
+
+	badSigTx := &Transaction{Sender: "alice", Inputs: []TxHashPointer{{TxHash: "seed", Index: 0}}, Outputs: []TxOutput{{Recipient: "bob", Amount: 40}}}
+	badSigTx.Signature = []byte("forged")
+
+	overspendTx := &Transaction{Sender: "alice", Inputs: []TxHashPointer{{TxHash: "seed", Index: 0}}, Outputs: []TxOutput{{Recipient: "bob", Amount: 1000}}}
+	overspendTx.Sign("alice")
+
+	unknownInputTx := &Transaction{Sender: "alice", Inputs: []TxHashPointer{{TxHash: "missing", Index: 0}}, Outputs: []TxOutput{{Recipient: "bob", Amount: 10}}}
+	unknownInputTx.Sign("alice")
+
+	coinbaseShaped := NewCoinbaseTx("alice", 1000)
+
+	tests := []struct {
+		name string
+		tx   *Transaction
+		want PushCode
+	}{
+		{"valid", validTx, ValidTransaction},
+		{"bad signature", badSigTx, BadSignature},
+		{"insufficient funds", overspendTx, InsufficientFunds},
+		{"unknown input", unknownInputTx, DoubleSpend},
+		{"coinbase rejected from mempool", coinbaseShaped, BadSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMempool()
+			if got := m.Push(tt.tx, utxo); got != tt.want {
+				t.Fatalf("Push() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	m := NewMempool()
+	if got := m.Push(validTx, utxo); got != ValidTransaction {
+		t.Fatalf("first push: got %v, want ValidTransaction", got)
+	}
+	if got := m.Push(validTx, utxo); got != AlreadyInPool {
+		t.Fatalf("expected AlreadyInPool on repush, got %v", got)
+	}
+}